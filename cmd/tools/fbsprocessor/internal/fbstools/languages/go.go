@@ -1,31 +1,99 @@
 package languages
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"hash/fnv"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/tools/imports"
 )
 
 var _ LanguageProcessor = (*GoProcessor)(nil)
 
+// FlatcRunner invokes the flatc compiler for a single schema file,
+// writing generated sources into outputDir. Watch calls this for every
+// schema that changed before re-running ProcessFile/PostProcess; without
+// one, Watch still reacts to changes but no generated sources are
+// produced, and PostProcess only rewrites flatdatas_helper.go.
+type FlatcRunner func(ctx context.Context, schemaFile, outputDir string) error
+
 // GoProcessor handles post-processing of Go FlatBuffer files.
 type GoProcessor struct {
-	flatbuffers []string
+	flatbuffers   []string
+	manageImports bool
+	runFlatc      FlatcRunner
+	watchDebounce time.Duration
+	events        chan WatchEvent
+	eventsMu      sync.Mutex
+	eventsClosed  bool
+	transformers  []ASTTransformer
+}
+
+// GoProcessorOption configures a GoProcessor returned by NewGoProcessor.
+type GoProcessorOption func(*GoProcessor)
+
+// WithoutImportsManagement disables the goimports post-pass, falling back
+// to plain gofmt. Use this if you want to ship vendored output without
+// requiring golang.org/x/tools, or if you run the patched files through
+// your own import tooling. Note that patched sources which rely on
+// imports being added for you (e.g. the flatdatas_helper.go template)
+// will need their imports fixed up by hand when this is set.
+func WithoutImportsManagement() GoProcessorOption {
+	return func(p *GoProcessor) {
+		p.manageImports = false
+	}
+}
+
+// WithFlatcRunner sets the flatc invocation Watch uses for incremental
+// regeneration.
+func WithFlatcRunner(run FlatcRunner) GoProcessorOption {
+	return func(p *GoProcessor) {
+		p.runFlatc = run
+	}
+}
+
+// WithWatchDebounce overrides Watch's default 100ms debounce window.
+func WithWatchDebounce(d time.Duration) GoProcessorOption {
+	return func(p *GoProcessor) {
+		p.watchDebounce = d
+	}
+}
+
+// WithASTTransformers appends extra transformers to the default set (just
+// NameTransformer()), run against every struct type patchAST visits.
+func WithASTTransformers(transformers ...ASTTransformer) GoProcessorOption {
+	return func(p *GoProcessor) {
+		p.transformers = append(p.transformers, transformers...)
+	}
 }
 
 // NewGoProcessor creates a new Go processor.
-func NewGoProcessor() *GoProcessor {
-	return &GoProcessor{
-		flatbuffers: []string{},
+func NewGoProcessor(opts ...GoProcessorOption) *GoProcessor {
+	p := &GoProcessor{
+		flatbuffers:   []string{},
+		manageImports: true,
+		transformers:  []ASTTransformer{NameTransformer()},
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
 }
 
 // ProcessFile adds encryption to a Go FlatBuffer file.
@@ -40,31 +108,84 @@ func (p *GoProcessor) ProcessFile(filePath string) error {
 	if !p.isFlatBufferFile(tree) {
 		return ErrFlatBuffersNotImported
 	}
-	p.flatbuffers = append(p.flatbuffers, strings.TrimSuffix(filepath.Base(filePath), p.Extension()))
+	// Watch re-runs ProcessFile for the same file on every debounced edit,
+	// so guard against piling up duplicate names: PostProcess's registry
+	// template would otherwise emit the same map key twice and fail to
+	// compile with "duplicate key in map literal".
+	name := strings.TrimSuffix(filepath.Base(filePath), p.Extension())
+	if !containsString(p.flatbuffers, name) {
+		p.flatbuffers = append(p.flatbuffers, name)
+	}
 
-	// Modify the AST to add a func (*{{.}}) Name() function
-	if patchErr := patchAST(fset, tree); patchErr != nil {
+	// Run the registered AST transformers (Name() by default) over every
+	// struct type in the file.
+	if patchErr := p.patchAST(fset, tree); patchErr != nil {
 		return fmt.Errorf("failed to patch AST: %w", patchErr)
 	}
 
-	f, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer f.Close()
-	// Print the AST
-	if writeErr := printer.Fprint(f, fset, tree); writeErr != nil {
+	var buf bytes.Buffer
+	if writeErr := printer.Fprint(&buf, fset, tree); writeErr != nil {
 		return fmt.Errorf("failed to print AST: %w", writeErr)
 	}
 
+	// gofmt (and, unless disabled, goimports) the patched output and
+	// re-parse it before it ever touches disk, so a subtly broken patch
+	// (bad string literal, missing positions, misplaced comment) fails
+	// loudly instead of corrupting the file.
+	formatted, fmtErr := p.formatAndValidate(filePath, buf.Bytes())
+	if fmtErr != nil {
+		return fmt.Errorf("refusing to overwrite %s: %w", filePath, fmtErr)
+	}
+
+	if writeErr := os.WriteFile(filePath, formatted, 0o644); writeErr != nil {
+		return fmt.Errorf("failed to write file: %w", writeErr)
+	}
+
 	return nil
 }
 
+// formatAndValidate runs src through goimports (or plain gofmt, if import
+// management is disabled) and re-parses the result, so that a miscompiled
+// source never reaches disk. name is only used to annotate the file in
+// parse errors and, when goimports runs, to resolve its package path.
+func (p *GoProcessor) formatAndValidate(name string, src []byte) ([]byte, error) {
+	var formatted []byte
+	if p.manageImports {
+		imported, err := imports.Process(name, src, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to goimports %s: %w\n--- source ---\n%s", name, err, src)
+		}
+		formatted = imported
+	} else {
+		gofmted, err := format.Source(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to gofmt %s: %w\n--- source ---\n%s", name, err, src)
+		}
+		formatted = gofmted
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), name, formatted, parser.ParseComments); err != nil {
+		return nil, fmt.Errorf("formatted %s failed to re-parse: %w\n--- source ---\n%s", name, err, formatted)
+	}
+
+	return formatted, nil
+}
+
 // Extension returns the file extension for the language.
 func (*GoProcessor) Extension() string {
 	return ".go"
 }
 
+// containsString reports whether s is present in list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	FlatDataHelperFileName = "flatdatas_helper.go"
 )
@@ -77,25 +198,325 @@ func (p *GoProcessor) PostProcess(_ context.Context, outputDir string) error {
 	// Create a new file: flatdatas_helper.go
 	// Create a global variable: fbs and set it to the flatbuffers package
 	// Create a function: GetFlatDataByName(name string)
-	f, osErr := os.Create(filepath.Join(outputDir, FlatDataHelperFileName))
-	if osErr != nil {
-		return fmt.Errorf("failed to create file: %w", osErr)
-	}
-	defer f.Close()
-
-	// Write the file
 	tmpl, err := template.New("flatbufferCode").Parse(flatbufferCode)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %w", err)
 	}
-	// Execute the template. Output the result to the file
+
+	// Execute the template into a buffer first, not the destination file,
+	// so the same gofmt/re-parse safety net used in ProcessFile protects
+	// the generated helper too.
 	sort.Strings(p.flatbuffers)
-	if executeErr := tmpl.Execute(f, p.flatbuffers); executeErr != nil {
+	var buf bytes.Buffer
+	if executeErr := tmpl.Execute(&buf, p.flatbuffers); executeErr != nil {
 		return fmt.Errorf("failed to execute template: %w", executeErr)
 	}
+
+	formatted, fmtErr := p.formatAndValidate(filepath.Join(outputDir, FlatDataHelperFileName), buf.Bytes())
+	if fmtErr != nil {
+		return fmt.Errorf("refusing to write %s: %w", FlatDataHelperFileName, fmtErr)
+	}
+
+	if writeErr := os.WriteFile(filepath.Join(outputDir, FlatDataHelperFileName), formatted, 0o644); writeErr != nil {
+		return fmt.Errorf("failed to write file: %w", writeErr)
+	}
 	return nil
 }
 
+// WatchEvent reports the outcome of one incremental regeneration
+// triggered by Watch.
+type WatchEvent struct {
+	Schema string // path to the .fbs file that triggered regeneration
+	Stage  string // "start", "success", or "failure"
+	Err    error  // set when Stage == "failure"
+}
+
+// Events returns the channel Watch publishes WatchEvents on, so callers
+// can log them or surface them in a TUI. Call it before Watch; the
+// channel is closed when Watch returns.
+func (p *GoProcessor) Events() <-chan WatchEvent {
+	if p.events == nil {
+		p.events = make(chan WatchEvent, 16)
+	}
+	return p.events
+}
+
+func (p *GoProcessor) emit(evt WatchEvent) {
+	p.eventsMu.Lock()
+	defer p.eventsMu.Unlock()
+	if p.events == nil || p.eventsClosed {
+		return
+	}
+	select {
+	case p.events <- evt:
+	default:
+	}
+}
+
+// closeEvents closes the Events channel at most once, guarded by eventsMu
+// so a concurrent emit can never race a send against the close.
+func (p *GoProcessor) closeEvents() {
+	p.eventsMu.Lock()
+	defer p.eventsMu.Unlock()
+	if p.events != nil && !p.eventsClosed {
+		close(p.events)
+		p.eventsClosed = true
+	}
+}
+
+// Watch monitors schemaDir for .fbs changes and incrementally regenerates
+// the affected Go output in outputDir: PreProcess -> flatc -> ProcessFile
+// -> PostProcess, scoped to the schema that changed. Writes are debounced
+// (WithWatchDebounce) so an editor's save doesn't retrigger the pipeline
+// multiple times, and a hash of each schema's contents is kept so writes
+// that don't actually change the file (touch, metadata-only) are ignored.
+// It blocks until ctx is cancelled.
+func (p *GoProcessor) Watch(ctx context.Context, schemaDir, outputDir string) error {
+	defer p.closeEvents()
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create schema watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	hashes := map[string][sha256.Size]byte{}
+	if walkErr := filepath.WalkDir(schemaDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		if filepath.Ext(path) == ".fbs" {
+			if sum, hashErr := hashFile(path); hashErr == nil {
+				hashes[path] = sum
+			}
+		}
+		return nil
+	}); walkErr != nil {
+		return fmt.Errorf("failed to watch %s: %w", schemaDir, walkErr)
+	}
+
+	debounce := p.watchDebounce
+	if debounce <= 0 {
+		debounce = 100 * time.Millisecond
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	pending := map[string]*time.Timer{}
+
+	// On the way out, stop every debounce timer that hasn't fired yet and
+	// wait for any regenerate already running to finish, before closing
+	// Events. Without this, a timer firing after Watch returns would still
+	// run the pipeline, and emit would silently drop its events because
+	// Events is already closed.
+	defer func() {
+		mu.Lock()
+		for schema, t := range pending {
+			if t.Stop() {
+				delete(pending, schema)
+				wg.Done()
+			}
+		}
+		mu.Unlock()
+		wg.Wait()
+		p.closeEvents()
+	}()
+
+	trigger := func(schema string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if t, ok := pending[schema]; ok {
+			if t.Stop() {
+				wg.Done()
+			}
+		}
+		wg.Add(1)
+		var timer *time.Timer
+		timer = time.AfterFunc(debounce, func() {
+			mu.Lock()
+			// Only drop our own entry: a later trigger may already have
+			// replaced it with a newer timer that still has to be tracked.
+			if pending[schema] == timer {
+				delete(pending, schema)
+			}
+			mu.Unlock()
+			defer wg.Done()
+			p.regenerate(ctx, schemaDir, schema, outputDir)
+		})
+		pending[schema] = timer
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case fsEvt, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if fsEvt.Op&fsnotify.Create != 0 {
+				// A directory created under schemaDir isn't watched until
+				// we explicitly Add it, so a .fbs file later written
+				// inside it would otherwise go unnoticed forever.
+				if info, statErr := os.Stat(fsEvt.Name); statErr == nil && info.IsDir() {
+					p.watchNewDir(watcher, fsEvt.Name, hashes, trigger)
+					continue
+				}
+			}
+			if filepath.Ext(fsEvt.Name) != ".fbs" || fsEvt.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			sum, hashErr := hashFile(fsEvt.Name)
+			if hashErr != nil {
+				continue
+			}
+			if prev, ok := hashes[fsEvt.Name]; ok && prev == sum {
+				continue
+			}
+			hashes[fsEvt.Name] = sum
+			trigger(fsEvt.Name)
+		case watchErr, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			p.emit(WatchEvent{Stage: "failure", Err: watchErr})
+		}
+	}
+}
+
+// watchNewDir extends the watch to a directory created under schemaDir
+// after Watch already started (fsnotify.Watcher.Add isn't recursive), and
+// triggers regeneration for any .fbs files it already contains so schemas
+// dropped in alongside their new directory aren't missed.
+func (p *GoProcessor) watchNewDir(watcher *fsnotify.Watcher, dir string, hashes map[string][sha256.Size]byte, trigger func(string)) {
+	walkErr := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		if filepath.Ext(path) != ".fbs" {
+			return nil
+		}
+		sum, hashErr := hashFile(path)
+		if hashErr != nil {
+			return nil
+		}
+		hashes[path] = sum
+		trigger(path)
+		return nil
+	})
+	if walkErr != nil {
+		p.emit(WatchEvent{Stage: "failure", Err: fmt.Errorf("watch new dir %s: %w", dir, walkErr)})
+	}
+}
+
+// regenerate re-runs the pipeline for a single changed schema and reports
+// the outcome through Events. schemaDir is PreProcess's directory-wide
+// argument per the LanguageProcessor contract; schema is just the one
+// file that changed, used to scope the flatc invocation.
+func (p *GoProcessor) regenerate(ctx context.Context, schemaDir, schema, outputDir string) {
+	p.emit(WatchEvent{Schema: schema, Stage: "start"})
+
+	if err := p.PreProcess(ctx, schemaDir); err != nil {
+		p.emit(WatchEvent{Schema: schema, Stage: "failure", Err: fmt.Errorf("preprocess: %w", err)})
+		return
+	}
+
+	before, snapshotErr := goFileModTimes(outputDir)
+	if snapshotErr != nil {
+		p.emit(WatchEvent{Schema: schema, Stage: "failure", Err: fmt.Errorf("snapshot output dir: %w", snapshotErr)})
+		return
+	}
+
+	if p.runFlatc != nil {
+		if err := p.runFlatc(ctx, schema, outputDir); err != nil {
+			p.emit(WatchEvent{Schema: schema, Stage: "failure", Err: fmt.Errorf("flatc: %w", err)})
+			return
+		}
+	}
+
+	// flatc generates one file per type, not one per schema, so the set
+	// of files this schema change actually affected has to be discovered
+	// rather than guessed from the schema's own name.
+	affected, diffErr := changedGoFiles(outputDir, before)
+	if diffErr != nil {
+		p.emit(WatchEvent{Schema: schema, Stage: "failure", Err: fmt.Errorf("diff output dir: %w", diffErr)})
+		return
+	}
+
+	for _, file := range affected {
+		if err := p.ProcessFile(file); err != nil {
+			p.emit(WatchEvent{Schema: schema, Stage: "failure", Err: fmt.Errorf("process %s: %w", file, err)})
+			return
+		}
+	}
+
+	if err := p.PostProcess(ctx, outputDir); err != nil {
+		p.emit(WatchEvent{Schema: schema, Stage: "failure", Err: fmt.Errorf("postprocess: %w", err)})
+		return
+	}
+
+	p.emit(WatchEvent{Schema: schema, Stage: "success"})
+}
+
+// goFileModTimes snapshots the mtime of every .go file directly in dir, so
+// a later call can tell which ones flatc just wrote or rewrote. A missing
+// dir snapshots as empty rather than erroring, since flatc may be about to
+// create it.
+func goFileModTimes(dir string) (map[string]time.Time, error) {
+	snapshot := map[string]time.Time{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snapshot, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".go" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		snapshot[filepath.Join(dir, entry.Name())] = info.ModTime()
+	}
+	return snapshot, nil
+}
+
+// changedGoFiles returns the .go files in dir that are new or modified
+// relative to the before snapshot.
+func changedGoFiles(dir string, before map[string]time.Time) ([]string, error) {
+	after, err := goFileModTimes(dir)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for path, mtime := range after {
+		if prev, ok := before[path]; !ok || mtime.After(prev) {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// hashFile returns a content hash of path, used to tell apart genuine
+// schema edits from no-op writes (e.g. a touch, or an editor re-saving
+// identical content).
+func hashFile(path string) ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
 func (*GoProcessor) isFlatBufferFile(file *ast.File) bool {
 	// First check if the file imports the flatbuffers package
 	if !hasFlatBuffersImport(file) {
@@ -144,33 +565,70 @@ func usesFlatBuffersTable(file *ast.File) bool {
 	return result
 }
 
-func hasNameMethod(tree *ast.File) bool {
-	methodFound := false
+// hasMethodForType reports whether tree already declares a method named
+// methodName on typeName (pointer or value receiver).
+func hasMethodForType(tree *ast.File, typeName, methodName string) bool {
+	found := false
 	ast.Inspect(tree, func(n ast.Node) bool {
-		if n == nil {
-			return false
-		}
-
 		funcDecl, ok := n.(*ast.FuncDecl)
-		if !ok {
+		if !ok || funcDecl.Name.Name != methodName || funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 {
 			return true
 		}
 
-		if funcDecl.Name.Name == "Name" {
-			methodFound = true
-			return false
+		switch recvType := funcDecl.Recv.List[0].Type.(type) {
+		case *ast.StarExpr:
+			if ident, ok := recvType.X.(*ast.Ident); ok && ident.Name == typeName {
+				found = true
+			}
+		case *ast.Ident:
+			if recvType.Name == typeName {
+				found = true
+			}
 		}
-
-		return false
+		return true
 	})
-	return methodFound
+	return found
 }
 
-func patchAST(fset *token.FileSet, tree *ast.File) error {
-	if hasNameMethod(tree) {
-		return nil
+// flatBuffersTableFieldName returns the name of the struct field of type
+// flatbuffers.Table in structType, if any.
+func flatBuffersTableFieldName(structType *ast.StructType) string {
+	for _, field := range structType.Fields.List {
+		sel, ok := field.Type.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || x.Name != "flatbuffers" || sel.Sel.Name != "Table" {
+			continue
+		}
+		if len(field.Names) == 0 {
+			continue
+		}
+		return field.Names[0].Name
 	}
+	return ""
+}
+
+// ASTTransformer synthesizes extra declarations for a flatbuffer struct
+// type encountered while patching a generated file. It's invoked once per
+// struct type that survives the usesFlatBuffersTable filter. Implementations
+// must be idempotent: check whether their method is already present (see
+// hasMethodForType) before emitting it, so repeated runs on an
+// already-patched file are no-ops.
+type ASTTransformer interface {
+	Transform(fset *token.FileSet, file *ast.File, typeSpec *ast.TypeSpec) ([]ast.Decl, error)
+}
+
+// ASTTransformerFunc adapts a plain function to ASTTransformer.
+type ASTTransformerFunc func(fset *token.FileSet, file *ast.File, typeSpec *ast.TypeSpec) ([]ast.Decl, error)
+
+func (f ASTTransformerFunc) Transform(fset *token.FileSet, file *ast.File, typeSpec *ast.TypeSpec) ([]ast.Decl, error) {
+	return f(fset, file, typeSpec)
+}
 
+func (p *GoProcessor) patchAST(fset *token.FileSet, tree *ast.File) error {
+	var structs []*ast.TypeSpec
 	ast.Inspect(tree, func(n ast.Node) bool {
 		genDecl, ok := n.(*ast.GenDecl)
 		if !ok || genDecl.Tok != token.TYPE {
@@ -182,59 +640,367 @@ func patchAST(fset *token.FileSet, tree *ast.File) error {
 			if !ok {
 				continue
 			}
-
-			// Check if the type is a struct
 			if _, ok := typeSpec.Type.(*ast.StructType); !ok {
 				continue
 			}
+			structs = append(structs, typeSpec)
+		}
+		return true
+	})
+
+	for _, typeSpec := range structs {
+		// Registrable requires Init(buf []byte, offset flatbuffers.UOffsetT).
+		// flatc already generates this for every root table, so this only
+		// fires for the rare struct it skipped; synthesize a minimal
+		// delegate to the struct's named flatbuffers.Table field rather than
+		// reimplementing flatc's offset arithmetic. This is core support
+		// for the typed registry, not a pluggable transformer.
+		if !hasMethodForType(tree, typeSpec.Name.Name, "Init") {
+			structType := typeSpec.Type.(*ast.StructType)
+			if fieldName := flatBuffersTableFieldName(structType); fieldName != "" {
+				tree.Decls = append(tree.Decls, newInitMethod(typeSpec.Name.Name, fieldName))
+			}
+		}
+
+		for _, transformer := range p.transformers {
+			decls, err := transformer.Transform(fset, tree, typeSpec)
+			if err != nil {
+				return fmt.Errorf("transformer failed for %s: %w", typeSpec.Name.Name, err)
+			}
+			tree.Decls = append(tree.Decls, decls...)
+		}
+	}
+	return nil
+}
+
+// nameTransformer emits func (*T) Name() string { return "T" }. It's the
+// one built-in transformer enabled by default.
+type nameTransformer struct{}
+
+// NameTransformer emits the Name() string method every registered
+// flatdata type needs.
+func NameTransformer() ASTTransformer { return nameTransformer{} }
+
+func (nameTransformer) Transform(_ *token.FileSet, file *ast.File, typeSpec *ast.TypeSpec) ([]ast.Decl, error) {
+	if hasMethodForType(file, typeSpec.Name.Name, "Name") {
+		return nil, nil
+	}
+	return []ast.Decl{newNameMethod(typeSpec.Name.Name)}, nil
+}
+
+// schemaHashTransformer emits a precomputed fnv64a hash of the type name
+// and the method names flatBufferAccessors reports for it, useful as a schema-evolution guard: if a consumer's
+// SchemaHash() disagrees with what it expects, the schema moved under it.
+type schemaHashTransformer struct{}
+
+// SchemaHashTransformer emits a SchemaHash() uint64 method.
+func SchemaHashTransformer() ASTTransformer { return schemaHashTransformer{} }
+
+func (schemaHashTransformer) Transform(_ *token.FileSet, file *ast.File, typeSpec *ast.TypeSpec) ([]ast.Decl, error) {
+	if hasMethodForType(file, typeSpec.Name.Name, "SchemaHash") {
+		return nil, nil
+	}
+	// flatc exposes actual schema fields only as accessor methods (e.g.
+	// `func (rcv *T) Name() string`); the Go struct itself is always just
+	// an embedded flatbuffers.Table, so hashing typeSpec's struct fields
+	// would hash the same thing for every type regardless of schema.
+	sum := fnv.New64a()
+	sum.Write([]byte(typeSpec.Name.Name))
+	for _, field := range flatBufferAccessors(file, typeSpec.Name.Name) {
+		sum.Write([]byte(field))
+	}
+
+	return []ast.Decl{&ast.FuncDecl{
+		Recv: &ast.FieldList{List: []*ast.Field{{Type: &ast.StarExpr{X: ast.NewIdent(typeSpec.Name.Name)}}}},
+		Name: ast.NewIdent("SchemaHash"),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("uint64")}}},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{ast.NewIdent(fmt.Sprintf("0x%x", sum.Sum64()))},
+				},
+			},
+		},
+	}}, nil
+}
+
+// jsonTransformer emits a MarshalJSON that delegates to the flatbuffer
+// accessors flatc already generated, and an UnmarshalJSON that does not
+// decode anything and always returns an error (see newUnmarshalJSONMethod).
+type jsonTransformer struct{}
+
+// JSONTransformer emits MarshalJSON/UnmarshalJSON methods.
+func JSONTransformer() ASTTransformer { return jsonTransformer{} }
+
+func (jsonTransformer) Transform(_ *token.FileSet, file *ast.File, typeSpec *ast.TypeSpec) ([]ast.Decl, error) {
+	var decls []ast.Decl
+	if !hasMethodForType(file, typeSpec.Name.Name, "MarshalJSON") {
+		decls = append(decls, newMarshalJSONMethod(file, typeSpec.Name.Name))
+	}
+	if !hasMethodForType(file, typeSpec.Name.Name, "UnmarshalJSON") {
+		decls = append(decls, newUnmarshalJSONMethod(typeSpec.Name.Name))
+	}
+	return decls, nil
+}
+
+// flatBufferAccessors returns the names of typeName's zero-argument,
+// single-result pointer-receiver methods, which covers flatc's accessors
+// for scalar and string schema fields (e.g. `func (rcv *T) Name() string`),
+// skipping the handful of same-shaped methods added elsewhere in this
+// package. Other zero-argument, single-result methods flatc generates, such
+// as Table() or a vector's XLength(), are included as well; accessors that
+// take an argument (struct, table and vector element fields) are not.
+func flatBufferAccessors(file *ast.File, typeName string) []string {
+	var names []string
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 {
+			continue
+		}
+		star, ok := funcDecl.Recv.List[0].Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		if ident, ok := star.X.(*ast.Ident); !ok || ident.Name != typeName {
+			continue
+		}
+		if funcDecl.Type.Params != nil && len(funcDecl.Type.Params.List) > 0 {
+			continue
+		}
+		if funcDecl.Type.Results == nil || len(funcDecl.Type.Results.List) != 1 {
+			continue
+		}
+		switch funcDecl.Name.Name {
+		case "Name", "Init", "SchemaHash", "MarshalJSON", "UnmarshalJSON", "String":
+			continue
+		}
+		names = append(names, funcDecl.Name.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newMarshalJSONMethod builds a MarshalJSON that marshals a map of every
+// field accessor's current value.
+func newMarshalJSONMethod(file *ast.File, typeName string) ast.Decl {
+	elts := make([]ast.Expr, 0)
+	for _, field := range flatBufferAccessors(file, typeName) {
+		elts = append(elts, &ast.KeyValueExpr{
+			Key: &ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", field)},
+			Value: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{X: ast.NewIdent("rcv"), Sel: ast.NewIdent(field)},
+			},
+		})
+	}
 
-			// Create the new method
-			funcDecl := &ast.FuncDecl{
-				Recv: &ast.FieldList{
-					List: []*ast.Field{
-						{
-							Type: &ast.StarExpr{X: ast.NewIdent(typeSpec.Name.Name)},
+	return &ast.FuncDecl{
+		Recv: &ast.FieldList{List: []*ast.Field{{
+			Names: []*ast.Ident{ast.NewIdent("rcv")},
+			Type:  &ast.StarExpr{X: ast.NewIdent(typeName)},
+		}}},
+		Name: ast.NewIdent("MarshalJSON"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{
+				{Type: &ast.ArrayType{Elt: ast.NewIdent("byte")}},
+				{Type: ast.NewIdent("error")},
+			}},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{&ast.CallExpr{
+						Fun: &ast.SelectorExpr{X: ast.NewIdent("json"), Sel: ast.NewIdent("Marshal")},
+						Args: []ast.Expr{&ast.CompositeLit{
+							Type: &ast.MapType{Key: ast.NewIdent("string"), Value: ast.NewIdent("any")},
+							Elts: elts,
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// newUnmarshalJSONMethod builds an UnmarshalJSON stub. A flatbuffer table
+// is a read-only view over a byte buffer it doesn't own, so there's no
+// generic way to mutate one in place from JSON; doing so correctly would
+// mean rebuilding the buffer from scratch with a flatbuffers.Builder,
+// which needs schema-aware codegen this AST pass doesn't have. Returning
+// an explicit error beats silently doing nothing.
+func newUnmarshalJSONMethod(typeName string) ast.Decl {
+	return &ast.FuncDecl{
+		Recv: &ast.FieldList{List: []*ast.Field{{
+			Names: []*ast.Ident{ast.NewIdent("rcv")},
+			Type:  &ast.StarExpr{X: ast.NewIdent(typeName)},
+		}}},
+		Name: ast.NewIdent("UnmarshalJSON"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{ast.NewIdent("_")}, Type: &ast.ArrayType{Elt: ast.NewIdent("byte")}},
+			}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("error")}}},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{&ast.CallExpr{
+						Fun: &ast.SelectorExpr{X: ast.NewIdent("fmt"), Sel: ast.NewIdent("Errorf")},
+						Args: []ast.Expr{&ast.BasicLit{
+							Kind:  token.STRING,
+							Value: fmt.Sprintf("%q", typeName+" is backed by an immutable flatbuffer and can't be unmarshaled in place; build a new one with a flatbuffers.Builder"),
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// newNameMethod builds `func (*T) Name() string { return "T" }`.
+func newNameMethod(typeName string) *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Recv: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Type: &ast.StarExpr{X: ast.NewIdent(typeName)},
+				},
+			},
+		},
+		Name: ast.NewIdent("Name"),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("string")}}},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{ast.NewIdent(fmt.Sprintf("\"%s\"", typeName))},
+				},
+			},
+		},
+	}
+}
+
+// newInitMethod builds `func (rcv *T) Init(buf []byte, offset flatbuffers.UOffsetT) { rcv.<field>.Bytes = buf; rcv.<field>.Pos = offset }`.
+func newInitMethod(typeName, tableFieldName string) *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Recv: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{ast.NewIdent("rcv")},
+					Type:  &ast.StarExpr{X: ast.NewIdent(typeName)},
+				},
+			},
+		},
+		Name: ast.NewIdent("Init"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("buf")},
+						Type:  &ast.ArrayType{Elt: ast.NewIdent("byte")},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("offset")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent("flatbuffers"),
+							Sel: ast.NewIdent("UOffsetT"),
 						},
 					},
 				},
-				Name: ast.NewIdent("Name"),
-				Type: &ast.FuncType{
-					Params:  &ast.FieldList{},
-					Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("string")}}},
+			},
+		},
+		// flatbuffers.Table is a plain {Bytes []byte; Pos UOffsetT} struct,
+		// not a type with its own Init method, so the table field's
+		// Bytes and Pos are assigned directly, the same way flatc's own generated
+		// Init methods do.
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{&ast.SelectorExpr{
+						X: &ast.SelectorExpr{
+							X:   ast.NewIdent("rcv"),
+							Sel: ast.NewIdent(tableFieldName),
+						},
+						Sel: ast.NewIdent("Bytes"),
+					}},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{ast.NewIdent("buf")},
 				},
-				Body: &ast.BlockStmt{
-					List: []ast.Stmt{
-						&ast.ReturnStmt{
-							Results: []ast.Expr{ast.NewIdent(fmt.Sprintf("\"%s\"", typeSpec.Name.Name))},
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{&ast.SelectorExpr{
+						X: &ast.SelectorExpr{
+							X:   ast.NewIdent("rcv"),
+							Sel: ast.NewIdent(tableFieldName),
 						},
-					},
+						Sel: ast.NewIdent("Pos"),
+					}},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{ast.NewIdent("offset")},
 				},
-			}
-
-			// Add the new method to the file
-			tree.Decls = append(tree.Decls, funcDecl)
-		}
-		return true
-	})
-	return nil
+			},
+		},
+	}
 }
 
+// flatbufferCode intentionally omits its imports: formatAndValidate runs
+// this through goimports, which resolves and inserts them. If a future
+// change needs another package here, just reference it by identifier and
+// let goimports add the import spec.
 const flatbufferCode = `package flatdata
 
-import (
-	"reflect"
-)
+// Registrable is implemented by every generated flatbuffer root table
+// registered below.
+type Registrable interface {
+	Name() string
+	Init(buf []byte, offset flatbuffers.UOffsetT)
+}
 
-var fbs = map[string]reflect.Type{
+var registry = map[string]func() Registrable{
 {{- range . }}
-	"{{ . }}": reflect.TypeOf((*{{ . }})(nil)).Elem(),
+	"{{ . }}": func() Registrable { return &{{ . }}{} },
 {{- end }}
 }
 
+// Get looks up name in the registry and type-asserts it to T, so callers
+// no longer need to do that themselves.
+func Get[T Registrable](name string) (T, bool) {
+	var zero T
+	ctor, ok := registry[name]
+	if !ok {
+		return zero, false
+	}
+	v, ok := ctor().(T)
+	if !ok {
+		return zero, false
+	}
+	return v, true
+}
+
+// Decode looks up name in the registry and initializes it from the root
+// table offset of buf.
+func Decode(name string, buf []byte) (Registrable, error) {
+	ctor, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("flatdata: no type registered for %q", name)
+	}
+	data := ctor()
+	data.Init(buf, flatbuffers.GetUOffsetT(buf))
+	return data, nil
+}
+
+// GetFlatDataByName is kept for existing callers: it returns a fresh,
+// uninitialized instance of the registered type, same as the reflect-based
+// implementation it replaces. Prefer Get or Decode in new code.
 func GetFlatDataByName(name string) any {
-	if data, ok := fbs[name]; ok {
-		return reflect.New(data).Interface()
+	ctor, ok := registry[name]
+	if !ok {
+		return nil
 	}
-	return nil
+	return ctor()
 }
 `
@@ -0,0 +1,45 @@
+package languages
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrFlatBuffersNotImported is returned by ProcessFile when a generated
+// file doesn't import the flatbuffers runtime, meaning it isn't a
+// FlatBuffer file and shouldn't be patched.
+var ErrFlatBuffersNotImported = errors.New("file does not import flatbuffers")
+
+// LanguageProcessor post-processes flatc-generated output for a single
+// target language: patching individual files, and emitting any
+// language-wide helper artifacts once every file has been seen.
+type LanguageProcessor interface {
+	// Extension returns the file extension flatc generates for this
+	// language, e.g. ".go".
+	Extension() string
+
+	// PreProcess runs before flatc is invoked for schemaDir.
+	PreProcess(ctx context.Context, schemaDir string) error
+
+	// ProcessFile patches a single flatc-generated file in place.
+	ProcessFile(filePath string) error
+
+	// PostProcess runs once after every generated file in outputDir has
+	// been through ProcessFile, to emit language-wide helper artifacts.
+	PostProcess(ctx context.Context, outputDir string) error
+
+	// Watch monitors schemaDir for schema changes and incrementally
+	// regenerates the affected output under outputDir. It blocks until
+	// ctx is cancelled. Processors that don't support incremental
+	// regeneration can embed NopWatcher to satisfy this as a no-op.
+	Watch(ctx context.Context, schemaDir, outputDir string) error
+}
+
+// NopWatcher is embeddable by LanguageProcessor implementations that
+// don't support incremental regeneration.
+type NopWatcher struct{}
+
+// Watch is a no-op.
+func (NopWatcher) Watch(context.Context, string, string) error {
+	return nil
+}